@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Service describes a LaunchAgent plist. It's the in-memory representation
+// rendered by generatePlist, and is kept separate from the rendering so the
+// same struct can later back an `edit --set key=value` mode.
+type Service struct {
+	Name                 string
+	Program              string
+	ProgramArguments     []string
+	EnvironmentVariables map[string]string
+	StdoutPath           string
+	StderrPath           string
+	UserName             string
+	KeepAlive            bool
+	RunAtLoad            bool
+	StartInterval        int
+	WorkingDirectory     string
+}
+
+// xmlEscape escapes a value for safe interpolation into the plist template,
+// since a bare "&"/"<"/">" (common in program arguments, e.g. shell flags
+// or URLs) would otherwise produce a malformed XML document.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+var plistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Name | xml}}</string>
+	<key>Program</key>
+	<string>{{.Program | xml}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .ProgramArguments}}
+		<string>{{. | xml}}</string>
+{{- end}}
+	</array>
+{{- if .WorkingDirectory}}
+	<key>WorkingDirectory</key>
+	<string>{{.WorkingDirectory | xml}}</string>
+{{- end}}
+{{- if .EnvironmentVariables}}
+	<key>EnvironmentVariables</key>
+	<dict>
+{{- range $key, $value := .EnvironmentVariables}}
+		<key>{{$key | xml}}</key>
+		<string>{{$value | xml}}</string>
+{{- end}}
+	</dict>
+{{- end}}
+{{- if .UserName}}
+	<key>UserName</key>
+	<string>{{.UserName | xml}}</string>
+{{- end}}
+{{- if .StdoutPath}}
+	<key>StandardOutPath</key>
+	<string>{{.StdoutPath | xml}}</string>
+{{- end}}
+{{- if .StderrPath}}
+	<key>StandardErrorPath</key>
+	<string>{{.StderrPath | xml}}</string>
+{{- end}}
+	<key>KeepAlive</key>
+	<{{if .KeepAlive}}true{{else}}false{{end}}/>
+	<key>RunAtLoad</key>
+	<{{if .RunAtLoad}}true{{else}}false{{end}}/>
+{{- if .StartInterval}}
+	<key>StartInterval</key>
+	<integer>{{.StartInterval}}</integer>
+{{- end}}
+</dict>
+</plist>
+`))
+
+// renderPlist renders svc as a LaunchAgent plist document.
+func renderPlist(svc Service) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := plistTemplate.Execute(&buf, svc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// envFlags collects repeated `--env KEY=VAL` flags into a map.
+type envFlags map[string]string
+
+func (e envFlags) String() string {
+	return fmt.Sprint(map[string]string(e))
+}
+
+func (e envFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid --env value %q, expected KEY=VAL", value)
+	}
+	e[key] = val
+	return nil
+}
+
+// splitFlagsAndPositionals separates argv into flag tokens and positional
+// tokens, preserving the order within each group, so a flag.FlagSet can
+// parse the former while the latter (here, label and program) are taken as
+// plain arguments regardless of where they fall relative to the flags.
+// boolFlags lists flag names that never consume a following token as their
+// value, matching the flag package's own handling of bool flags.
+func splitFlagsAndPositionals(argv []string, boolFlags map[string]bool) (flags []string, positional []string) {
+	for i := 0; i < len(argv); i++ {
+		a := argv[i]
+
+		if !strings.HasPrefix(a, "-") {
+			positional = append(positional, a)
+			continue
+		}
+
+		flags = append(flags, a)
+
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") || boolFlags[name] {
+			continue
+		}
+		if i+1 < len(argv) {
+			i++
+			flags = append(flags, argv[i])
+		}
+	}
+
+	return flags, positional
+}
+
+// generatePlist implements the `generate`/`new` subcommand: it renders a new
+// LaunchAgent plist from flags and writes it into launchAgentsPath, so users
+// don't have to hand-write XML before running `lunchy install`.
+func generatePlist(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+
+	var (
+		argsFlag  string
+		workdir   string
+		keepAlive bool
+		runAtLoad bool
+		stdout    string
+		stderr    string
+		interval  int
+		user      string
+	)
+
+	env := envFlags{}
+
+	fs.StringVar(&argsFlag, "args", "", "space-separated arguments passed to the program")
+	fs.StringVar(&workdir, "workdir", "", "working directory for the program")
+	fs.Var(env, "env", "environment variable in KEY=VAL form, repeatable")
+	fs.BoolVar(&keepAlive, "keepalive", false, "restart the program if it exits")
+	fs.BoolVar(&runAtLoad, "runatload", false, "start the program as soon as it is loaded")
+	fs.StringVar(&stdout, "stdout", "", "directory to write the stdout log into")
+	fs.StringVar(&stderr, "stderr", "", "directory to write the stderr log into")
+	fs.IntVar(&interval, "interval", 0, "run the program every N seconds, instead of once")
+	fs.StringVar(&user, "user", "", "run the program as this user (system scope only)")
+
+	if len(args) < 3 {
+		return fmt.Errorf("usage: lunchy generate <label> <program> [--args ...] [--workdir ...] [--env KEY=VAL ...]")
+	}
+
+	flagArgs, positional := splitFlagsAndPositionals(args[2:], map[string]bool{"keepalive": true, "runatload": true})
+
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+
+	if len(positional) < 2 {
+		return fmt.Errorf("usage: lunchy generate <label> <program> [--args ...] [--workdir ...] [--env KEY=VAL ...]")
+	}
+
+	label, program := positional[0], positional[1]
+
+	if label != filepath.Base(label) || label == ".." {
+		return fmt.Errorf("invalid label %q: must not contain a path separator", label)
+	}
+
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	var stdoutPath, stderrPath string
+	if stdout != "" {
+		stdoutPath = filepath.Join(stdout, label+".out.log")
+	}
+	if stderr != "" {
+		stderrPath = filepath.Join(stderr, label+".err.log")
+	}
+
+	programArguments := append([]string{program}, strings.Fields(argsFlag)...)
+
+	svc := Service{
+		Name:                 label,
+		Program:              program,
+		ProgramArguments:     programArguments,
+		EnvironmentVariables: map[string]string(env),
+		StdoutPath:           stdoutPath,
+		StderrPath:           stderrPath,
+		UserName:             user,
+		KeepAlive:            keepAlive,
+		RunAtLoad:            runAtLoad,
+		StartInterval:        interval,
+		WorkingDirectory:     workdir,
+	}
+
+	contents, err := renderPlist(svc)
+	if err != nil {
+		return fmt.Errorf("failed to render plist: %s", err)
+	}
+
+	path := pPath(label)
+
+	if err := os.WriteFile(path, contents, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %s", path, err)
+	}
+
+	fmt.Println("generated", path)
+	return nil
+}
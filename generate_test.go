@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// plistStrings does just enough XML walking to pull <key>/<string> pairs
+// back out of a rendered plist, so tests can assert on values without
+// pulling in a full plist library.
+func plistStrings(data []byte) (map[string]string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	result := map[string]string{}
+	var lastKey string
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "key":
+			var key string
+			if err := dec.DecodeElement(&key, &se); err != nil {
+				return nil, err
+			}
+			lastKey = key
+		case "string":
+			var val string
+			if err := dec.DecodeElement(&val, &se); err != nil {
+				return nil, err
+			}
+			result[lastKey] = val
+		}
+	}
+
+	return result, nil
+}
+
+func TestRenderPlist(t *testing.T) {
+	svc := Service{
+		Name:                 "com.example.myapp",
+		Program:              "/usr/local/bin/myapp",
+		ProgramArguments:     []string{"/usr/local/bin/myapp", "--verbose"},
+		EnvironmentVariables: map[string]string{"FOO": "bar"},
+		StdoutPath:           "/tmp/logs/com.example.myapp.out.log",
+		StderrPath:           "/tmp/logs/com.example.myapp.err.log",
+		KeepAlive:            true,
+		RunAtLoad:            true,
+		StartInterval:        60,
+		WorkingDirectory:     "/tmp",
+	}
+
+	out, err := renderPlist(svc)
+	if err != nil {
+		t.Fatalf("renderPlist returned error: %s", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"plist"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("rendered plist is not well-formed XML: %s", err)
+	}
+
+	strs, err := plistStrings(out)
+	if err != nil {
+		t.Fatalf("failed to walk rendered plist: %s", err)
+	}
+
+	wantStrings := map[string]string{
+		"Label":             svc.Name,
+		"Program":           svc.Program,
+		"WorkingDirectory":  svc.WorkingDirectory,
+		"StandardOutPath":   "/tmp/logs/com.example.myapp.out.log",
+		"StandardErrorPath": "/tmp/logs/com.example.myapp.err.log",
+		"FOO":               "bar",
+	}
+
+	for key, want := range wantStrings {
+		if got := strs[key]; got != want {
+			t.Errorf("key %q: got %q, want %q", key, got, want)
+		}
+	}
+
+	if !bytes.Contains(out, []byte("<true/>")) {
+		t.Errorf("expected KeepAlive/RunAtLoad to render as <true/>, got:\n%s", out)
+	}
+
+	if !bytes.Contains(out, []byte("<integer>60</integer>")) {
+		t.Errorf("expected StartInterval to render as an integer, got:\n%s", out)
+	}
+}
+
+func TestRenderPlistEscapesXML(t *testing.T) {
+	svc := Service{
+		Name:                 "com.example.escaped",
+		Program:              "/usr/bin/foo",
+		ProgramArguments:     []string{"/usr/bin/foo", "--name=Tom & Jerry", "<tag>"},
+		EnvironmentVariables: map[string]string{"A&B": "1 < 2"},
+	}
+
+	out, err := renderPlist(svc)
+	if err != nil {
+		t.Fatalf("renderPlist returned error: %s", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"plist"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("rendered plist is not well-formed XML: %s", err)
+	}
+
+	strs, err := plistStrings(out)
+	if err != nil {
+		t.Fatalf("failed to walk rendered plist: %s", err)
+	}
+
+	if got, want := strs["A&B"], "1 < 2"; got != want {
+		t.Errorf("env value: got %q, want %q", got, want)
+	}
+}
+
+func TestRenderPlistMinimal(t *testing.T) {
+	svc := Service{
+		Name:             "com.example.bare",
+		Program:          "/bin/echo",
+		ProgramArguments: []string{"/bin/echo"},
+	}
+
+	out, err := renderPlist(svc)
+	if err != nil {
+		t.Fatalf("renderPlist returned error: %s", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"plist"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("rendered plist is not well-formed XML: %s", err)
+	}
+
+	if bytes.Contains(out, []byte("StandardOutPath")) {
+		t.Errorf("expected no log paths without StdoutPath/StderrPath, got:\n%s", out)
+	}
+
+	if !bytes.Contains(out, []byte("<false/>")) {
+		t.Errorf("expected KeepAlive/RunAtLoad to render as <false/>, got:\n%s", out)
+	}
+}
+
+func TestGeneratePlistFlagsInterspersedWithPositionals(t *testing.T) {
+	dir := t.TempDir()
+	orig := launchAgentsPath
+	launchAgentsPath = dir
+	defer func() { launchAgentsPath = orig }()
+
+	args := []string{"lunchy", "generate", "com.example.test", "/bin/echo", "--keepalive", "--runatload", "--env", "FOO=bar", "--workdir", "/tmp"}
+	if err := generatePlist(args); err != nil {
+		t.Fatalf("generatePlist returned error: %s", err)
+	}
+
+	out, err := os.ReadFile(filepath.Join(dir, "com.example.test.plist"))
+	if err != nil {
+		t.Fatalf("failed to read generated plist: %s", err)
+	}
+
+	strs, err := plistStrings(out)
+	if err != nil {
+		t.Fatalf("failed to walk rendered plist: %s", err)
+	}
+
+	if got, want := strs["WorkingDirectory"], "/tmp"; got != want {
+		t.Errorf("WorkingDirectory: got %q, want %q", got, want)
+	}
+
+	if got, want := strs["FOO"], "bar"; got != want {
+		t.Errorf("env FOO: got %q, want %q", got, want)
+	}
+
+	if !bytes.Contains(out, []byte("<true/>")) {
+		t.Errorf("expected KeepAlive/RunAtLoad to render as <true/>, got:\n%s", out)
+	}
+}
+
+func TestRenderPlistIndependentLogPathsAndUser(t *testing.T) {
+	svc := Service{
+		Name:       "com.example.onlyout",
+		Program:    "/bin/echo",
+		StdoutPath: "/var/log/onlyout.log",
+		UserName:   "daemon",
+	}
+
+	out, err := renderPlist(svc)
+	if err != nil {
+		t.Fatalf("renderPlist returned error: %s", err)
+	}
+
+	strs, err := plistStrings(out)
+	if err != nil {
+		t.Fatalf("failed to walk rendered plist: %s", err)
+	}
+
+	if got, want := strs["StandardOutPath"], svc.StdoutPath; got != want {
+		t.Errorf("StandardOutPath: got %q, want %q", got, want)
+	}
+
+	if bytes.Contains(out, []byte("StandardErrorPath")) {
+		t.Errorf("expected no StandardErrorPath when StderrPath is unset, got:\n%s", out)
+	}
+
+	if got, want := strs["UserName"], svc.UserName; got != want {
+		t.Errorf("UserName: got %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -13,14 +14,40 @@ import (
 
 const (
 	lunchyVersion = "0.2.1"
+
+	systemLaunchDaemonsPath = "/Library/LaunchDaemons"
 )
 
 var (
 	launchAgentsPath = filepath.Join(os.Getenv("HOME"), "Library", "LaunchAgents")
+
+	// systemScope is set by the --system/-s flag and switches every path and
+	// launchctl invocation from the user's LaunchAgents to the system-wide
+	// LaunchDaemons.
+	systemScope = false
 )
 
+// scopePath returns the LaunchAgents or LaunchDaemons directory, depending on
+// whether --system was given.
+func scopePath() string {
+	if systemScope {
+		return systemLaunchDaemonsPath
+	}
+	return launchAgentsPath
+}
+
 func pPath(n string) string {
-	return filepath.Join(launchAgentsPath, n+".plist")
+	return filepath.Join(scopePath(), n+".plist")
+}
+
+// requireRoot refuses to touch the system scope unless we're already root,
+// since a non-root write to /Library/LaunchDaemons would just fail halfway
+// through with a confusing permission error.
+func requireRoot() error {
+	if systemScope && os.Geteuid() != 0 {
+		return fmt.Errorf("modifying %s requires root; re-run with sudo", systemLaunchDaemonsPath)
+	}
+	return nil
 }
 
 func fileExists(path string) bool {
@@ -49,85 +76,121 @@ func fileCopy(src string, dst string) error {
 	return d.Close()
 }
 
-func findPlists(path string) []string {
-	output, err := exec.Command("find", "-L", path, "-name", "*.plist", "-type", "f").Output()
+// PlistFile pairs a discovered plist's name with the path it was found at.
+// The name alone isn't enough to rebuild the path once a scan recurses into
+// a directory tree that doesn't mirror scopePath's flat layout (e.g. `lunchy
+// scan homebrew`), so findPlists keeps both instead of discarding the path.
+type PlistFile struct {
+	Name string
+	Path string
+}
+
+// findPlists walks path looking for *.plist files, the same way
+// `find -L path -name *.plist -type f` used to. Symlinked directories are
+// followed manually (WalkDir itself does not do this), with a visited set
+// to guard against symlink cycles.
+func findPlists(path string) []PlistFile {
+	var plists []PlistFile
+	walkPlists(path, &plists, map[string]bool{})
+
+	sort.Slice(plists, func(i, j int) bool { return plists[i].Name < plists[j].Name })
+
+	return plists
+}
+
+func walkPlists(dir string, plists *[]PlistFile, visited map[string]bool) {
+	real, err := filepath.EvalSymlinks(dir)
 	if err != nil {
-		return nil
+		real = dir
 	}
+	if visited[real] {
+		return
+	}
+	visited[real] = true
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	plists := make([]string, 0, len(lines))
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
 
-	for _, line := range lines {
-		plists = append(plists, strings.Replace(filepath.Base(line), ".plist", "", 1))
-	}
+		if d.Type()&fs.ModeSymlink != 0 {
+			info, statErr := os.Stat(p)
+			if statErr != nil {
+				return nil
+			}
 
-	sort.Sort(sort.StringSlice(plists))
+			if info.IsDir() {
+				walkPlists(p, plists, visited)
+				return nil
+			}
 
-	return plists
+			if filepath.Ext(p) == ".plist" {
+				*plists = append(*plists, PlistFile{Name: strings.TrimSuffix(filepath.Base(p), ".plist"), Path: p})
+			}
+			return nil
+		}
+
+		if d.IsDir() || filepath.Ext(p) != ".plist" {
+			return nil
+		}
+
+		*plists = append(*plists, PlistFile{Name: strings.TrimSuffix(filepath.Base(p), ".plist"), Path: p})
+		return nil
+	})
 }
 
+// getPlists returns the bare names of every plist installed in scopePath,
+// for callers that only need to match or address them by name (scopePath's
+// layout is flat, so pPath(name) reconstructs the same path findPlists saw).
 func getPlists() []string {
-	return findPlists(launchAgentsPath)
+	files := findPlists(scopePath())
+
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+
+	return names
 }
 
-func sliceIncludes(slice []string, match string) bool {
-	for _, val := range slice {
-		if val == match {
-			return true
+// matchingPlists returns every installed plist whose name contains pattern.
+func matchingPlists(pattern string) []string {
+	var matched []string
+
+	for _, plist := range getPlists() {
+		if strings.Contains(plist, pattern) {
+			matched = append(matched, plist)
 		}
 	}
 
-	return false
+	return matched
 }
 
 func printUsage(_ []string) error {
 	fmt.Printf("Lunchy %s, the friendly launchctl wrapper\n", lunchyVersion)
-	fmt.Println("Usage: lunchy [start|stop|restart|list|status|install|show|edit|remove|scan] [options]")
+	fmt.Println("Usage: lunchy [--system|-s] [--format=text|json|yaml] [start|stop|restart|watch|list|status|install|generate|show|edit|remove|scan] [options]")
+	fmt.Println("  --system, -s   manage /Library/LaunchDaemons instead of ~/Library/LaunchAgents")
+	fmt.Println("  --format       output format for list/status/scan (default: text)")
+	fmt.Println("  --path         also watch this file/directory with `lunchy watch`")
 	return nil
 }
 
 func printList(_ []string) error {
-	for _, file := range getPlists() {
-		fmt.Println(file)
-	}
-	return nil
-}
-
-func printStatus(args []string) error {
-	out, err := exec.Command("launchctl", "list").Output()
+	files := findPlists(scopePath())
 
-	if err != nil {
-		return fmt.Errorf("failed to get process list: %s", err)
-	}
-
-	pattern := ""
-
-	if len(args) == 3 {
-		pattern = args[2]
-	}
-
-	installed := getPlists()
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-
-	for _, line := range lines {
-		chunks := strings.Split(line, "\t")
-
-		// Only show services from the user's LaunchAgents.
-		if !sliceIncludes(installed, chunks[2]) {
-			continue
-		}
-
-		// Filter on service name, if given.
-		if len(pattern) > 0 && !strings.Contains(chunks[2], pattern) {
-			continue
+	if outputFormat == formatText {
+		for _, f := range files {
+			fmt.Println(f.Name)
 		}
+		return nil
+	}
 
-		// Replace tabs with spaces to condense output.
-		fmt.Println(strings.Replace(line, "\t", " ", -1))
+	entries := make([]PlistEntry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, plistEntry(f.Name, f.Path))
 	}
 
-	return nil
+	return printFormatted(entries)
 }
 
 func assertValidArgs(args []string, msg string) {
@@ -137,37 +200,20 @@ func assertValidArgs(args []string, msg string) {
 	}
 }
 
-func withProfile(f func(string) error) func(args []string) error {
-	return func(args []string) error {
-		// Check if name pattern is not given and try profiles
-		if len(args) == 2 {
-			p, err := readProfile()
-			if err != nil {
-				return err
-			}
-			if p == nil {
-				return fmt.Errorf("name required")
-			}
-			return plistsAction(p, f)
-		}
-
-		name := args[2]
-
-		for _, plist := range getPlists() {
-			if strings.Contains(plist, name) {
-				f(plist)
-			}
-		}
-
-		return nil
-	}
-}
-
 func runLaunchCtl(verb string, name string) error {
 	path := pPath(name)
-	_, err := exec.Command("launchctl", verb, path).Output()
 
-	if err != nil {
+	var cmd *exec.Cmd
+	switch {
+	case systemScope && os.Geteuid() != 0:
+		cmd = exec.Command("sudo", "launchctl", verb, "-w", path)
+	case systemScope:
+		cmd = exec.Command("launchctl", verb, "-w", path)
+	default:
+		cmd = exec.Command("launchctl", verb, path)
+	}
+
+	if _, err := cmd.Output(); err != nil {
 		return fmt.Errorf("failed to %s %s: %s", verb, name, err)
 	}
 
@@ -194,13 +240,12 @@ func withFirstMatch(f func(string) error) func([]string) error {
 		assertValidArgs(args, "name required")
 		name := args[2]
 
-		for _, plist := range getPlists() {
-			if strings.Contains(plist, name) {
-				return f(plist)
-			}
+		matches := matchingPlists(name)
+		if len(matches) == 0 {
+			return fmt.Errorf("not found: %s", name)
 		}
 
-		return fmt.Errorf("not found: %s", name)
+		return f(matches[0])
 	}
 }
 
@@ -218,6 +263,10 @@ func showPlist(name string) error {
 }
 
 func editPlist(name string) error {
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
 	path := pPath(name)
 	editor := os.Getenv("EDITOR")
 
@@ -239,6 +288,10 @@ func editPlist(name string) error {
 func installPlist(args []string) error {
 	assertValidArgs(args, "path required")
 
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
 	path := args[2]
 
 	if !fileExists(path) {
@@ -246,7 +299,7 @@ func installPlist(args []string) error {
 	}
 
 	info, _ := os.Stat(path)
-	newPath := filepath.Join(launchAgentsPath, info.Name())
+	newPath := filepath.Join(scopePath(), info.Name())
 
 	if fileExists(newPath) && os.Remove(newPath) != nil {
 		return fmt.Errorf("unable to delete existing plist")
@@ -256,24 +309,26 @@ func installPlist(args []string) error {
 		return fmt.Errorf("failed to copy file")
 	}
 
-	fmt.Println(path, "installed to", launchAgentsPath)
+	fmt.Println(path, "installed to", scopePath())
 	return nil
 }
 
 func removePlist(args []string) error {
 	assertValidArgs(args, "name required")
 
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
 	name := args[2]
 
-	for _, plist := range getPlists() {
-		if strings.Contains(plist, name) {
-			path := pPath(plist)
+	for _, plist := range matchingPlists(name) {
+		path := pPath(plist)
 
-			if os.Remove(path) == nil {
-				fmt.Println("removed", path)
-			} else {
-				fmt.Println("failed to remove", path)
-			}
+		if os.Remove(path) == nil {
+			fmt.Println("removed", path)
+		} else {
+			fmt.Println("failed to remove", path)
 		}
 	}
 
@@ -281,7 +336,7 @@ func removePlist(args []string) error {
 }
 
 func scanPath(args []string) error {
-	path := launchAgentsPath
+	path := scopePath()
 
 	if len(args) >= 3 {
 		path = args[2]
@@ -292,94 +347,84 @@ func scanPath(args []string) error {
 		path = "/usr/local/Cellar"
 	}
 
-	for _, f := range findPlists(path) {
-		fmt.Println(f)
-	}
+	files := findPlists(path)
 
-	return nil
-}
-
-// Get daemon names specified in lunchy profile
-func readProfile() ([]string, error) {
-	dir, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
-	path := filepath.Join(dir, ".lunchy")
-
-	if !fileExists(path) {
-		return nil, nil
-	}
-
-	fmt.Println("Using daemons in profile:", path)
-	buff, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(buff)), "\n")
-	result := make([]string, 0, len(lines))
-
-	for _, l := range lines {
-		line := strings.TrimSpace(l)
-
-		// Skip comments (starts with #)
-		if line[0] == '#' {
-			continue
+	if outputFormat == formatText {
+		for _, f := range files {
+			fmt.Println(f.Name)
 		}
+		return nil
+	}
 
-		result = append(result, line)
+	entries := make([]PlistEntry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, plistEntry(f.Name, f.Path))
 	}
 
-	return result, nil
+	return printFormatted(entries)
 }
 
-func plistsAction(names []string, f func(string) error) error {
-	plists := getPlists()
-
-	for _, name := range names {
-		for _, plist := range plists {
-			if strings.Contains(plist, name) {
-				if err := f(plist); err != nil {
-					fmt.Println(err)
-				}
-			}
+// parseGlobalFlags strips the --system/-s and --format flags out of argv,
+// wherever they appear, and sets systemScope/outputFormat. The remaining
+// arguments keep their positions (program name, verb, name/pattern, ...) so
+// the rest of the code can keep indexing into them the same way.
+func parseGlobalFlags(argv []string) []string {
+	args := make([]string, 0, len(argv))
+
+	for i := 0; i < len(argv); i++ {
+		a := argv[i]
+
+		switch {
+		case a == "--system" || a == "-s":
+			systemScope = true
+		case strings.HasPrefix(a, "--format="):
+			outputFormat = strings.TrimPrefix(a, "--format=")
+		case a == "--format" && i+1 < len(argv):
+			i++
+			outputFormat = argv[i]
+		default:
+			args = append(args, a)
 		}
 	}
 
-	return nil
+	return args
 }
 
 func main() {
-	if len(os.Args) == 1 {
-		printUsage(os.Args)
+	args := parseGlobalFlags(os.Args)
+
+	if len(args) == 1 {
+		printUsage(args)
 		os.Exit(1)
 	}
 
 	f, ok := map[string](func([]string) error){
-		"add":     installPlist,
-		"edit":    withFirstMatch(editPlist),
-		"help":    printUsage,
-		"install": installPlist,
-		"list":    printList,
-		"ls":      printList,
-		"ps":      printStatus,
-		"remove":  removePlist,
-		"restart": withProfile(stopStartDaemon),
-		"rm":      removePlist,
-		"scan":    scanPath,
-		"show":    withFirstMatch(showPlist),
-		"start":   withProfile(startDaemon),
-		"status":  printStatus,
-		"stop":    withProfile(stopDaemon),
-	}[os.Args[1]]
+		"add":      installPlist,
+		"edit":     withFirstMatch(editPlist),
+		"generate": generatePlist,
+		"help":     printUsage,
+		"install":  installPlist,
+		"list":     printList,
+		"ls":       printList,
+		"new":      generatePlist,
+		"ps":       printStatus,
+		"remove":   removePlist,
+		"restart":  withProfile(stopStartDaemon),
+		"rm":       removePlist,
+		"scan":     scanPath,
+		"show":     withFirstMatch(showPlist),
+		"start":    withProfile(startDaemon),
+		"status":   printStatus,
+		"stop":     withProfile(stopDaemon),
+		"watch":    watchDaemons,
+	}[args[1]]
 
 	if !ok {
-		printUsage(os.Args)
+		printUsage(args)
 		os.Exit(1)
 	}
 
-	err := f(os.Args)
+	err := f(args)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
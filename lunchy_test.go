@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func plistNames(files []PlistFile) []string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func TestFindPlists(t *testing.T) {
+	dir := t.TempDir()
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("failed to create sub dir: %s", err)
+	}
+
+	nested := filepath.Join(sub, "com.example.nested.plist")
+	top := filepath.Join(dir, "com.example.top.plist")
+
+	for _, p := range []string{top, nested, filepath.Join(dir, "not-a-plist.txt")} {
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %s", p, err)
+		}
+	}
+
+	got := findPlists(dir)
+	want := []string{"com.example.nested", "com.example.top"}
+
+	if !reflect.DeepEqual(plistNames(got), want) {
+		t.Errorf("findPlists names = %#v, want %#v", plistNames(got), want)
+	}
+
+	wantPaths := map[string]string{
+		"com.example.nested": nested,
+		"com.example.top":    top,
+	}
+	for _, f := range got {
+		if f.Path != wantPaths[f.Name] {
+			t.Errorf("findPlists path for %q = %q, want %q", f.Name, f.Path, wantPaths[f.Name])
+		}
+	}
+}
+
+func TestFindPlistsFollowsSymlinkedDir(t *testing.T) {
+	dir := t.TempDir()
+
+	real := filepath.Join(dir, "real")
+	if err := os.Mkdir(real, 0755); err != nil {
+		t.Fatalf("failed to create real dir: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(real, "com.example.linked.plist"), nil, 0644); err != nil {
+		t.Fatalf("failed to write plist: %s", err)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks not supported on this filesystem: %s", err)
+	}
+
+	got := findPlists(dir)
+	want := []string{"com.example.linked"}
+
+	if !reflect.DeepEqual(plistNames(got), want) {
+		t.Errorf("findPlists names = %#v, want %#v", plistNames(got), want)
+	}
+}
+
+func TestFindPlistsSymlinkCycle(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "com.example.cycle.plist"), nil, 0644); err != nil {
+		t.Fatalf("failed to write plist: %s", err)
+	}
+
+	loop := filepath.Join(dir, "loop")
+	if err := os.Symlink(dir, loop); err != nil {
+		t.Skipf("symlinks not supported on this filesystem: %s", err)
+	}
+
+	done := make(chan []PlistFile, 1)
+	go func() {
+		done <- findPlists(dir)
+	}()
+
+	select {
+	case got := <-done:
+		want := []string{"com.example.cycle"}
+		if !reflect.DeepEqual(plistNames(got), want) {
+			t.Errorf("findPlists names = %#v, want %#v", plistNames(got), want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("findPlists did not return, symlink cycle guard is not working")
+	}
+}
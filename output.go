@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// outputFormat is set by the --format flag and controls how printList,
+// printStatus and scanPath render their results.
+var outputFormat = formatText
+
+// PlistEntry is the machine-readable representation of a plist on disk, as
+// emitted by `list`/`scan --format=json|yaml`.
+type PlistEntry struct {
+	Name    string    `json:"name" yaml:"name"`
+	Path    string    `json:"path" yaml:"path"`
+	Exists  bool      `json:"exists" yaml:"exists"`
+	ModTime time.Time `json:"modTime,omitempty" yaml:"modTime,omitempty"`
+	Size    int64     `json:"size" yaml:"size"`
+}
+
+// StatusEntry is the machine-readable representation of an installed
+// daemon's runtime status, as emitted by `status --format=json|yaml`.
+type StatusEntry struct {
+	Label          string `json:"label" yaml:"label"`
+	PID            *int   `json:"pid" yaml:"pid"`
+	LastExitStatus int    `json:"lastExitStatus" yaml:"lastExitStatus"`
+	Installed      bool   `json:"installed" yaml:"installed"`
+	PlistPath      string `json:"plistPath" yaml:"plistPath"`
+}
+
+// plistEntry stats path to build the PlistEntry for name.
+func plistEntry(name string, path string) PlistEntry {
+	entry := PlistEntry{Name: name, Path: path}
+
+	if info, err := os.Stat(path); err == nil {
+		entry.Exists = true
+		entry.ModTime = info.ModTime()
+		entry.Size = info.Size()
+	}
+
+	return entry
+}
+
+// printFormatted renders v as outputFormat to stdout.
+func printFormatted(v interface{}) error {
+	switch outputFormat {
+	case formatJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal json: %s", err)
+		}
+		fmt.Println(string(data))
+	case formatYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal yaml: %s", err)
+		}
+		fmt.Print(string(data))
+	default:
+		return fmt.Errorf("unknown format: %s", outputFormat)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// captureStdout redirects os.Stdout for the duration of f and returns
+// whatever was written to it, so printFormatted/printList/scanPath (which
+// all write straight to stdout) can be asserted on.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %s", err)
+	}
+
+	orig := os.Stdout
+	os.Stdout = w
+	f()
+	w.Close()
+	os.Stdout = orig
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatalf("failed to read captured stdout: %s", err)
+	}
+
+	return buf.String()
+}
+
+func withOutputFormat(t *testing.T, format string, f func()) {
+	t.Helper()
+
+	orig := outputFormat
+	outputFormat = format
+	defer func() { outputFormat = orig }()
+
+	f()
+}
+
+func TestPrintFormattedJSON(t *testing.T) {
+	entries := []PlistEntry{{Name: "com.example.foo", Path: "/tmp/com.example.foo.plist", Exists: true, Size: 42}}
+
+	var out string
+	withOutputFormat(t, formatJSON, func() {
+		out = captureStdout(t, func() {
+			if err := printFormatted(entries); err != nil {
+				t.Fatalf("printFormatted returned error: %s", err)
+			}
+		})
+	})
+
+	var got []PlistEntry
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, out)
+	}
+
+	if len(got) != 1 || got[0].Name != entries[0].Name || got[0].Path != entries[0].Path || got[0].Size != entries[0].Size {
+		t.Errorf("got %#v, want entry matching %#v", got, entries[0])
+	}
+}
+
+func TestPrintFormattedYAML(t *testing.T) {
+	entries := []PlistEntry{{Name: "com.example.foo", Path: "/tmp/com.example.foo.plist"}}
+
+	var out string
+	withOutputFormat(t, formatYAML, func() {
+		out = captureStdout(t, func() {
+			if err := printFormatted(entries); err != nil {
+				t.Fatalf("printFormatted returned error: %s", err)
+			}
+		})
+	})
+
+	var got []PlistEntry
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not valid YAML: %s\n%s", err, out)
+	}
+
+	if len(got) != 1 || got[0].Name != entries[0].Name || got[0].Path != entries[0].Path {
+		t.Errorf("got %#v, want entry matching %#v", got, entries[0])
+	}
+}
+
+func TestPrintFormattedUnknownFormat(t *testing.T) {
+	withOutputFormat(t, "xml", func() {
+		if err := printFormatted([]PlistEntry{}); err == nil {
+			t.Error("expected an error for an unknown format")
+		}
+	})
+}
+
+func TestPlistEntryMissingFile(t *testing.T) {
+	entry := plistEntry("com.example.missing", filepath.Join(t.TempDir(), "missing.plist"))
+
+	if entry.Exists {
+		t.Errorf("expected Exists=false for a missing file")
+	}
+}
+
+func TestScanPathNestedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("failed to create sub dir: %s", err)
+	}
+
+	nested := filepath.Join(sub, "com.example.nested.plist")
+	if err := os.WriteFile(nested, nil, 0644); err != nil {
+		t.Fatalf("failed to write plist: %s", err)
+	}
+
+	var out string
+	withOutputFormat(t, formatJSON, func() {
+		out = captureStdout(t, func() {
+			if err := scanPath([]string{"lunchy", "scan", dir}); err != nil {
+				t.Fatalf("scanPath returned error: %s", err)
+			}
+		})
+	})
+
+	var entries []PlistEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, out)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	if entries[0].Path != nested {
+		t.Errorf("Path = %q, want %q", entries[0].Path, nested)
+	}
+
+	if !entries[0].Exists {
+		t.Errorf("expected Exists=true for %q", entries[0].Path)
+	}
+}
+
+func TestPrintListNestedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	origPath := launchAgentsPath
+	launchAgentsPath = dir
+	defer func() { launchAgentsPath = origPath }()
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("failed to create sub dir: %s", err)
+	}
+
+	nested := filepath.Join(sub, "com.example.nested.plist")
+	if err := os.WriteFile(nested, nil, 0644); err != nil {
+		t.Fatalf("failed to write plist: %s", err)
+	}
+
+	var out string
+	withOutputFormat(t, formatJSON, func() {
+		out = captureStdout(t, func() {
+			if err := printList(nil); err != nil {
+				t.Fatalf("printList returned error: %s", err)
+			}
+		})
+	})
+
+	var entries []PlistEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, out)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	if entries[0].Path != nested {
+		t.Errorf("Path = %q, want %q", entries[0].Path, nested)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// profileFileName is the name of the profile file lunchy looks for, either
+// via LUNCHY_PROFILE or by walking up from the working directory.
+const profileFileName = ".lunchy"
+
+// Profile is a parsed .lunchy file: a default, unnamed list of daemon
+// patterns, plus any number of named [group] sections.
+type Profile struct {
+	Default  []string
+	Sections map[string][]string
+}
+
+// findProfilePath locates the .lunchy file to use. LUNCHY_PROFILE takes
+// precedence; otherwise it walks up from the working directory looking for
+// .lunchy, the same way git walks up looking for .git. Returns "" if no
+// profile was found.
+func findProfilePath() (string, error) {
+	if p := os.Getenv("LUNCHY_PROFILE"); p != "" {
+		if !fileExists(p) {
+			return "", fmt.Errorf("LUNCHY_PROFILE is set to %q, but it does not exist", p)
+		}
+		return p, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, profileFileName)
+		if fileExists(candidate) {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// parseProfile parses the contents of a .lunchy file. Blank lines and `#`
+// comments (anywhere on the line, not just column 0) are ignored, and
+// `[group]` headers start a named section; everything above the first
+// header belongs to Default.
+func parseProfile(contents string) *Profile {
+	profile := &Profile{Sections: map[string][]string{}}
+	section := ""
+
+	for _, raw := range strings.Split(contents, "\n") {
+		line := raw
+
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := profile.Sections[section]; !ok {
+				profile.Sections[section] = nil
+			}
+			continue
+		}
+
+		if section == "" {
+			profile.Default = append(profile.Default, line)
+		} else {
+			profile.Sections[section] = append(profile.Sections[section], line)
+		}
+	}
+
+	return profile
+}
+
+// loadProfile finds and parses the .lunchy file, if any.
+func loadProfile() (*Profile, error) {
+	path, err := findProfilePath()
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	fmt.Println("Using daemons in profile:", path)
+
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProfile(string(buff)), nil
+}
+
+// readProfile returns the default (unsectioned) daemon patterns from the
+// .lunchy file, or nil if there is no profile.
+func readProfile() ([]string, error) {
+	profile, err := loadProfile()
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	return profile.Default, nil
+}
+
+// readProfileGroup returns the patterns under [name] in the .lunchy file,
+// and whether that group exists at all.
+func readProfileGroup(name string) ([]string, bool, error) {
+	profile, err := loadProfile()
+	if err != nil || profile == nil {
+		return nil, false, err
+	}
+
+	patterns, ok := profile.Sections[name]
+	return patterns, ok, nil
+}
+
+// expandPatterns matches each pattern against the installed plists with
+// path.Match, so profile entries can use shell-style globs such as
+// "homebrew.mxcl.*".
+func expandPatterns(patterns []string) []string {
+	var matched []string
+
+	for _, pattern := range patterns {
+		for _, plist := range getPlists() {
+			if ok, err := path.Match(pattern, plist); err == nil && ok {
+				matched = append(matched, plist)
+			}
+		}
+	}
+
+	return matched
+}
+
+func plistsAction(patterns []string, f func(string) error) error {
+	for _, plist := range expandPatterns(patterns) {
+		if err := f(plist); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	return nil
+}
+
+// withProfile wraps a per-daemon action so it can be driven either by a
+// name/pattern argument or, with none given, by the .lunchy profile. A name
+// that matches a profile [group] runs the action over that group's
+// patterns; otherwise it's matched against installed plists directly.
+func withProfile(f func(string) error) func(args []string) error {
+	return func(args []string) error {
+		if len(args) == 2 {
+			names, err := readProfile()
+			if err != nil {
+				return err
+			}
+			if names == nil {
+				return fmt.Errorf("name required")
+			}
+			return plistsAction(names, f)
+		}
+
+		name := args[2]
+
+		patterns, ok, err := readProfileGroup(name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return plistsAction(patterns, f)
+		}
+
+		for _, plist := range matchingPlists(name) {
+			f(plist)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantDefault []string
+		sections    map[string][]string
+	}{
+		{
+			name:        "empty",
+			input:       "",
+			wantDefault: nil,
+			sections:    map[string][]string{},
+		},
+		{
+			name:        "blank lines and comments",
+			input:       "\n# a top-of-file comment\n\nhomebrew.mxcl.redis\n   # indented comment\ncom.example.api # trailing comment\n",
+			wantDefault: []string{"homebrew.mxcl.redis", "com.example.api"},
+			sections:    map[string][]string{},
+		},
+		{
+			name:        "sections",
+			input:       "homebrew.mxcl.redis\n\n[web]\ncom.example.web\ncom.example.api\n\n[workers]\ncom.example.worker.*\n",
+			wantDefault: []string{"homebrew.mxcl.redis"},
+			sections: map[string][]string{
+				"web":     {"com.example.web", "com.example.api"},
+				"workers": {"com.example.worker.*"},
+			},
+		},
+		{
+			name:        "empty section",
+			input:       "[empty]\n",
+			wantDefault: nil,
+			sections:    map[string][]string{"empty": nil},
+		},
+		{
+			name:        "only comments",
+			input:       "# nothing here\n#\n",
+			wantDefault: nil,
+			sections:    map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := parseProfile(tt.input)
+
+			if !reflect.DeepEqual(profile.Default, tt.wantDefault) {
+				t.Errorf("Default = %#v, want %#v", profile.Default, tt.wantDefault)
+			}
+
+			if !reflect.DeepEqual(profile.Sections, tt.sections) {
+				t.Errorf("Sections = %#v, want %#v", profile.Sections, tt.sections)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		argv       []string
+		wantArgs   []string
+		wantSystem bool
+		wantFormat string
+	}{
+		{
+			name:       "no flags",
+			argv:       []string{"lunchy", "list"},
+			wantArgs:   []string{"lunchy", "list"},
+			wantSystem: false,
+			wantFormat: formatText,
+		},
+		{
+			name:       "--system before the verb",
+			argv:       []string{"lunchy", "--system", "list"},
+			wantArgs:   []string{"lunchy", "list"},
+			wantSystem: true,
+			wantFormat: formatText,
+		},
+		{
+			name:       "-s short flag after the verb",
+			argv:       []string{"lunchy", "list", "-s"},
+			wantArgs:   []string{"lunchy", "list"},
+			wantSystem: true,
+			wantFormat: formatText,
+		},
+		{
+			name:       "--format=value and --system combined, in either order",
+			argv:       []string{"lunchy", "--format=json", "list", "--system"},
+			wantArgs:   []string{"lunchy", "list"},
+			wantSystem: true,
+			wantFormat: formatJSON,
+		},
+		{
+			name:       "--format value as a separate token",
+			argv:       []string{"lunchy", "scan", "--format", "yaml", "/tmp"},
+			wantArgs:   []string{"lunchy", "scan", "/tmp"},
+			wantSystem: false,
+			wantFormat: formatYAML,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origSystem, origFormat := systemScope, outputFormat
+			systemScope, outputFormat = false, formatText
+			defer func() { systemScope, outputFormat = origSystem, origFormat }()
+
+			got := parseGlobalFlags(tt.argv)
+
+			if !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", got, tt.wantArgs)
+			}
+			if systemScope != tt.wantSystem {
+				t.Errorf("systemScope = %v, want %v", systemScope, tt.wantSystem)
+			}
+			if outputFormat != tt.wantFormat {
+				t.Errorf("outputFormat = %q, want %q", outputFormat, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestScopePath(t *testing.T) {
+	origSystem, origAgents := systemScope, launchAgentsPath
+	defer func() { systemScope, launchAgentsPath = origSystem, origAgents }()
+
+	launchAgentsPath = "/home/user/Library/LaunchAgents"
+
+	systemScope = false
+	if got, want := scopePath(), launchAgentsPath; got != want {
+		t.Errorf("scopePath() = %q, want %q", got, want)
+	}
+
+	systemScope = true
+	if got, want := scopePath(), systemLaunchDaemonsPath; got != want {
+		t.Errorf("scopePath() = %q, want %q", got, want)
+	}
+}
+
+func TestRequireRoot(t *testing.T) {
+	origSystem := systemScope
+	defer func() { systemScope = origSystem }()
+
+	systemScope = false
+	if err := requireRoot(); err != nil {
+		t.Errorf("requireRoot() with systemScope=false should never error, got: %s", err)
+	}
+
+	systemScope = true
+	isRoot := os.Geteuid() == 0
+	err := requireRoot()
+
+	if isRoot && err != nil {
+		t.Errorf("requireRoot() should not error when running as root, got: %s", err)
+	}
+	if !isRoot && err == nil {
+		t.Error("requireRoot() should error for --system when not running as root")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"howett.net/plist"
+)
+
+// plistDoc is the subset of a LaunchAgent/LaunchDaemon plist that `status`
+// cares about.
+type plistDoc struct {
+	Label            string   `plist:"Label"`
+	Program          string   `plist:"Program"`
+	ProgramArguments []string `plist:"ProgramArguments"`
+	RunAtLoad        bool     `plist:"RunAtLoad"`
+	KeepAlive        bool     `plist:"KeepAlive"`
+	StartInterval    int      `plist:"StartInterval"`
+}
+
+// readPlistDoc reads and parses the plist at path.
+func readPlistDoc(path string) (*plistDoc, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc plistDoc
+	if _, err := plist.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// launchctlDomain returns the launchctl domain target for the current
+// scope, e.g. "gui/501" or "system".
+func launchctlDomain() string {
+	if systemScope {
+		return "system"
+	}
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// runtimeStatus cross-references label with `launchctl print <domain>/label`
+// for its PID and last exit code. installed reports whether launchctl knows
+// about the label at all.
+func runtimeStatus(label string) (pid *int, lastExitStatus int, installed bool) {
+	out, err := exec.Command("launchctl", "print", launchctlDomain()+"/"+label).Output()
+	if err != nil {
+		return nil, 0, false
+	}
+
+	installed = true
+
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+
+		if v, ok := strings.CutPrefix(line, "pid = "); ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				pid = &n
+			}
+		}
+
+		if v, ok := strings.CutPrefix(line, "last exit code = "); ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				lastExitStatus = n
+			}
+		}
+	}
+
+	return pid, lastExitStatus, installed
+}
+
+func printStatus(args []string) error {
+	pattern := ""
+	if len(args) == 3 {
+		pattern = args[2]
+	}
+
+	statuses := make([]StatusEntry, 0)
+
+	for _, name := range getPlists() {
+		if len(pattern) > 0 && !strings.Contains(name, pattern) {
+			continue
+		}
+
+		label := name
+		if doc, err := readPlistDoc(pPath(name)); err == nil && doc.Label != "" {
+			label = doc.Label
+		}
+
+		pid, lastExitStatus, installed := runtimeStatus(label)
+
+		statuses = append(statuses, StatusEntry{
+			Label:          label,
+			PID:            pid,
+			LastExitStatus: lastExitStatus,
+			Installed:      installed,
+			PlistPath:      pPath(name),
+		})
+	}
+
+	if outputFormat == formatText {
+		for _, s := range statuses {
+			pidStr := "-"
+			if s.PID != nil {
+				pidStr = strconv.Itoa(*s.PID)
+			}
+			fmt.Println(pidStr, s.LastExitStatus, s.Label)
+		}
+		return nil
+	}
+
+	return printFormatted(statuses)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces bursts of filesystem events (editors commonly
+// write a file more than once per save) into a single restart.
+const watchDebounce = 500 * time.Millisecond
+
+// watchPlist restarts name's daemon whenever its plist, or extraPath (the
+// daemon's binary or config, if --path was given), is written or renamed,
+// debouncing bursts of events into a single restart.
+func watchPlist(name string, extraPath string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %s", err)
+	}
+	defer watcher.Close()
+
+	path := pPath(name)
+
+	if err := watcher.Add(path); err != nil {
+		return fmt.Errorf("failed to watch %s: %s", path, err)
+	}
+
+	fmt.Println("watching", path)
+
+	if extraPath != "" {
+		if err := watcher.Add(extraPath); err != nil {
+			return fmt.Errorf("failed to watch %s: %s", extraPath, err)
+		}
+		fmt.Println("watching", extraPath)
+	}
+
+	var timer *time.Timer
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Rename|fsnotify.Create) == 0 {
+				continue
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+
+			timer = time.AfterFunc(watchDebounce, func() {
+				fmt.Println("change detected, restarting", name)
+				if err := stopStartDaemon(name); err != nil {
+					fmt.Println(err)
+				}
+			})
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Println("watch error:", err)
+		}
+	}
+}
+
+// watchTargets resolves the plist names a `watch` invocation should cover,
+// sharing the same name/profile/group resolution as withProfile.
+func watchTargets(args []string) ([]string, error) {
+	if len(args) == 2 {
+		names, err := readProfile()
+		if err != nil {
+			return nil, err
+		}
+		if names == nil {
+			return nil, fmt.Errorf("name required")
+		}
+		return expandPatterns(names), nil
+	}
+
+	name := args[2]
+
+	patterns, ok, err := readProfileGroup(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return expandPatterns(patterns), nil
+	}
+
+	return matchingPlists(name), nil
+}
+
+// extractPathFlag pulls the optional `--path`/`--path=value` flag out of
+// argv, wherever it appears, and returns the remaining arguments alongside
+// the path value (empty if not given).
+func extractPathFlag(argv []string) ([]string, string) {
+	args := make([]string, 0, len(argv))
+	path := ""
+
+	for i := 0; i < len(argv); i++ {
+		a := argv[i]
+
+		switch {
+		case strings.HasPrefix(a, "--path="):
+			path = strings.TrimPrefix(a, "--path=")
+		case a == "--path" && i+1 < len(argv):
+			i++
+			path = argv[i]
+		default:
+			args = append(args, a)
+		}
+	}
+
+	return args, path
+}
+
+// watchDaemons implements the `watch` subcommand: given a service name or a
+// `.lunchy` profile, it watches every matching plist (plus, with --path, the
+// daemon's binary or config file) and restarts its daemon on change, turning
+// lunchy into a lightweight dev-loop supervisor. Profiles with several
+// daemons are watched simultaneously, each in its own goroutine; one target
+// failing to start doesn't stop the others.
+func watchDaemons(args []string) error {
+	args, watchPath := extractPathFlag(args)
+
+	targets, err := watchTargets(args)
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		return fmt.Errorf("no matching plists to watch")
+	}
+
+	var wg sync.WaitGroup
+	failures := make(chan error, len(targets))
+
+	for _, name := range targets {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := watchPlist(name, watchPath); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failures <- err
+			}
+		}(name)
+	}
+
+	wg.Wait()
+	close(failures)
+
+	if len(failures) == len(targets) {
+		return fmt.Errorf("failed to watch any of %d target(s)", len(targets))
+	}
+
+	return nil
+}